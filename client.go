@@ -50,8 +50,13 @@ func ConnectContext(ctx context.Context, cfg HasClientConfig, vault HasVaultConf
 		return nil, err
 	}
 
-	opts = append(opts, grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()))
-	opts = append(opts, grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
+	if retry := cfg.GetRetry(); retry.enabled() {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor(), unaryRetryInterceptor(retry)))
+		opts = append(opts, grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), streamRetryInterceptor(retry)))
+	} else {
+		opts = append(opts, grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()))
+		opts = append(opts, grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()))
+	}
 
 	if cfg.GetTLS().GetEnabled() {
 		t, err := NewClientTLSConfig(ctx, cfg.GetTLS(), vault)
@@ -65,27 +70,14 @@ func ConnectContext(ctx context.Context, cfg HasClientConfig, vault HasVaultConf
 		opts = append(opts, grpc.WithInsecure())
 	}
 
-	shared := cfg.GetToken().GetShared()
-	if len(shared.GetID()) > 0 || len(shared.GetFile()) > 0 || len(shared.GetSecret()) > 0 {
-		opts = append(opts, grpc.WithPerRPCCredentials(SharedContextCredentials{
-			tokenProvider: func(ctx context.Context) string {
-				ctx, span := tracer.Start(ctx, "TokenProvider")
-				defer span.End()
-
-				s, err := LoadKey(ctx, shared, vault, "secret")
-				if err != nil {
-					span.RecordError(ctx, err)
-					log.WithError(err).Error("client: could not load secret key")
-					return ""
-				}
-
-				return string(s)
-			},
-		}))
-	} else {
-		opts = append(opts, grpc.WithPerRPCCredentials(ContextCredentials{}))
+	perRPC, err := perRPCCredentials(ctx, cfg, vault)
+	if err != nil {
+		span.RecordError(ctx, err)
+		return nil, err
 	}
 
+	opts = append(opts, grpc.WithPerRPCCredentials(perRPC))
+
 	if cfg.GetWriteBufferSize() > 0 {
 		opts = append(opts, grpc.WithWriteBufferSize(cfg.GetWriteBufferSize()))
 	}
@@ -116,6 +108,10 @@ func ConnectContext(ctx context.Context, cfg HasClientConfig, vault HasVaultConf
 		}))
 	}
 
+	if sc := clientServiceConfig(cfg); len(sc) > 0 {
+		opts = append(opts, grpc.WithDefaultServiceConfig(sc))
+	}
+
 	if cfg.GetBlock() {
 		opts = append(opts, grpc.WithBlock())
 	}
@@ -149,5 +145,12 @@ func ConnectContext(ctx context.Context, cfg HasClientConfig, vault HasVaultConf
 		return nil, err
 	}
 
+	if health := cfg.GetHealthCheck(); health.Enabled && cfg.GetBlock() {
+		if err := waitForServing(ctx, conn, health); err != nil {
+			span.RecordError(ctx, err)
+			return nil, err
+		}
+	}
+
 	return conn, nil
 }
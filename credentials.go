@@ -0,0 +1,162 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"sync"
+	"time"
+	"github.com/switch-bit/orlop/errors"
+	"github.com/switch-bit/orlop/log"
+	"google.golang.org/grpc/credentials"
+)
+
+// CredentialProvider builds the per-RPC credentials used to authenticate outgoing
+// calls for a given token kind. Implementations are looked up by cfg.GetToken().GetKind()
+// and registered with RegisterCredentialProvider, so new auth schemes can be added
+// without touching ConnectContext.
+type CredentialProvider interface {
+	// PerRPCCredentials returns the credentials.PerRPCCredentials to attach to the
+	// client, loading whatever secrets it needs from vault via cfg.
+	PerRPCCredentials(ctx context.Context, cfg HasClientConfig, vault HasVaultConfig) (credentials.PerRPCCredentials, error)
+}
+
+// credentialProviders holds the registered providers, keyed by token kind.
+var credentialProviders = map[string]CredentialProvider{}
+
+// RegisterCredentialProvider registers provider under kind, so that a client
+// configured with token.kind == kind uses it to authenticate. Registering under an
+// already-registered kind replaces the previous provider.
+func RegisterCredentialProvider(kind string, provider CredentialProvider) {
+	credentialProviders[kind] = provider
+}
+
+func init() {
+	RegisterCredentialProvider("shared", sharedCredentialProvider{})
+	RegisterCredentialProvider("oauth2", oauth2CredentialProvider{})
+	RegisterCredentialProvider("google", googleCredentialProvider{})
+	RegisterCredentialProvider("aws-sigv4", awsSigV4CredentialProvider{})
+	RegisterCredentialProvider("jwt-file", jwtFileCredentialProvider{})
+	RegisterCredentialProvider("exec", execCredentialProvider{})
+}
+
+// perRPCCredentials resolves the credentials.PerRPCCredentials to dial with for cfg,
+// dispatching to the CredentialProvider registered for cfg.GetToken().GetKind(). When
+// no kind is configured it falls back to the legacy shared-secret/anonymous behavior.
+func perRPCCredentials(ctx context.Context, cfg HasClientConfig, vault HasVaultConfig) (credentials.PerRPCCredentials, error) {
+	kind := cfg.GetToken().GetKind()
+
+	if len(kind) == 0 {
+		shared := cfg.GetToken().GetShared()
+		if len(shared.GetID()) > 0 || len(shared.GetFile()) > 0 || len(shared.GetSecret()) > 0 {
+			kind = "shared"
+		} else {
+			return ContextCredentials{}, nil
+		}
+	}
+
+	provider, ok := credentialProviders[kind]
+	if !ok {
+		return nil, errors.New("client: unknown token kind " + kind)
+	}
+
+	return provider.PerRPCCredentials(ctx, cfg, vault)
+}
+
+// sharedCredentialProvider reproduces orlop's original behavior of authenticating
+// with a single Vault-loaded secret shared across all clients of a service.
+type sharedCredentialProvider struct{}
+
+func (sharedCredentialProvider) PerRPCCredentials(_ context.Context, cfg HasClientConfig, vault HasVaultConfig) (credentials.PerRPCCredentials, error) {
+	shared := cfg.GetToken().GetShared()
+
+	return SharedContextCredentials{
+		tokenProvider: func(ctx context.Context) string {
+			ctx, span := tracer.Start(ctx, "TokenProvider")
+			defer span.End()
+
+			s, err := LoadKey(ctx, shared, vault, "secret")
+			if err != nil {
+				span.RecordError(ctx, err)
+				log.WithError(err).Error("client: could not load secret key")
+				return ""
+			}
+
+			return string(s)
+		},
+	}, nil
+}
+
+// cachedToken holds a bearer token refreshed proactively ahead of its expiry, shared
+// by the oauth2, google, jwt-file and exec providers below.
+type cachedToken struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	jitter time.Duration
+	fetch  func(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+// Get returns a cached token if it is still fresh, otherwise fetches a new one.
+func (c *cachedToken) Get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.token) > 0 && time.Now().Before(c.expiry.Add(-c.jitter)) {
+		return c.token, nil
+	}
+
+	token, expiry, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expiry = expiry
+
+	return token, nil
+}
+
+// bearerPerRPCCredentials adapts a cachedToken into credentials.PerRPCCredentials,
+// sending it as a standard "Bearer" authorization header.
+type bearerPerRPCCredentials struct {
+	token                    *cachedToken
+	requireTransportSecurity bool
+}
+
+func (c bearerPerRPCCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	ctx, span := tracer.Start(ctx, "TokenProvider")
+	defer span.End()
+
+	token, err := c.token.Get(ctx)
+	if err != nil {
+		span.RecordError(ctx, err)
+		return nil, errors.Wrap(err, "client: failed to fetch token")
+	}
+
+	return map[string]string{
+		"authorization": "Bearer " + token,
+	}, nil
+}
+
+func (c bearerPerRPCCredentials) RequireTransportSecurity() bool {
+	return c.requireTransportSecurity
+}
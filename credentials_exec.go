@@ -0,0 +1,71 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"time"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+// execTokenResponse is the JSON shape an exec credential helper must print to
+// stdout: {"token": "...", "expiry": "<RFC3339 timestamp>"}.
+type execTokenResponse struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// execCredentialProvider authenticates by running an external helper binary and
+// parsing its JSON token response, for backends with bespoke token-issuance flows.
+type execCredentialProvider struct{}
+
+func (execCredentialProvider) PerRPCCredentials(_ context.Context, cfg HasClientConfig, _ HasVaultConfig) (credentials.PerRPCCredentials, error) {
+	command := cfg.GetToken().GetExec()
+	if len(command.GetCommand()) == 0 {
+		return nil, errors.New("client: exec token requires a command")
+	}
+
+	return bearerPerRPCCredentials{
+		requireTransportSecurity: true,
+		token: &cachedToken{
+			jitter: tokenRefreshJitter,
+			fetch: func(ctx context.Context) (string, time.Time, error) {
+				cmd := exec.CommandContext(ctx, command.GetCommand(), command.GetArgs()...)
+
+				out, err := cmd.Output()
+				if err != nil {
+					return "", time.Time{}, errors.Wrap(err, "client: exec token command failed")
+				}
+
+				var resp execTokenResponse
+				if err := json.Unmarshal(out, &resp); err != nil {
+					return "", time.Time{}, errors.Wrap(err, "client: failed to parse exec token response")
+				}
+
+				return resp.Token, resp.Expiry, nil
+			},
+		},
+	}, nil
+}
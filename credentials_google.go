@@ -0,0 +1,58 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"time"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+// googleCredentialProvider authenticates using Google Application Default
+// Credentials, delegating discovery and refresh entirely to golang.org/x/oauth2/google.
+type googleCredentialProvider struct{}
+
+func (googleCredentialProvider) PerRPCCredentials(ctx context.Context, cfg HasClientConfig, _ HasVaultConfig) (credentials.PerRPCCredentials, error) {
+	scopes := cfg.GetToken().GetGoogle().GetScopes()
+
+	creds, err := google.FindDefaultCredentials(ctx, scopes...)
+	if err != nil {
+		return nil, errors.Wrap(err, "client: failed to find google default credentials")
+	}
+
+	return bearerPerRPCCredentials{
+		requireTransportSecurity: true,
+		token: &cachedToken{
+			jitter: tokenRefreshJitter,
+			fetch: func(ctx context.Context) (string, time.Time, error) {
+				t, err := creds.TokenSource.Token()
+				if err != nil {
+					return "", time.Time{}, errors.Wrap(err, "client: failed to fetch google token")
+				}
+
+				return t.AccessToken, t.Expiry, nil
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+// jwtFileCredentialProvider authenticates with a JWT read from disk, re-reading the
+// file and refreshing the cached token whenever the embedded "exp" claim nears.
+type jwtFileCredentialProvider struct{}
+
+func (jwtFileCredentialProvider) PerRPCCredentials(_ context.Context, cfg HasClientConfig, _ HasVaultConfig) (credentials.PerRPCCredentials, error) {
+	path := cfg.GetToken().GetJWTFile().GetPath()
+	if len(path) == 0 {
+		return nil, errors.New("client: jwt-file token requires a path")
+	}
+
+	return bearerPerRPCCredentials{
+		requireTransportSecurity: true,
+		token: &cachedToken{
+			jitter: tokenRefreshJitter,
+			fetch: func(context.Context) (string, time.Time, error) {
+				raw, err := os.ReadFile(path)
+				if err != nil {
+					return "", time.Time{}, errors.Wrap(err, "client: failed to read jwt file")
+				}
+
+				token := strings.TrimSpace(string(raw))
+
+				claims := jwt.MapClaims{}
+				if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+					return "", time.Time{}, errors.Wrap(err, "client: failed to parse jwt expiry")
+				}
+
+				exp, ok := claims["exp"].(float64)
+				if !ok {
+					return "", time.Time{}, errors.New("client: jwt file token is missing an exp claim")
+				}
+
+				return token, time.Unix(int64(exp), 0), nil
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+var errTestFetch = errors.New("test: fetch failed")
+
+func TestCachedTokenGetReturnsCachedTokenBeforeExpiry(t *testing.T) {
+	fetches := 0
+	c := &cachedToken{
+		fetch: func(context.Context) (string, time.Time, error) {
+			fetches++
+			return "token", time.Now().Add(time.Hour), nil
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := c.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if token != "token" {
+			t.Errorf("Get() = %q, want %q", token, "token")
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("fetch called %d times, want 1", fetches)
+	}
+}
+
+func TestCachedTokenGetRefetchesWithinJitterOfExpiry(t *testing.T) {
+	fetches := 0
+	c := &cachedToken{
+		jitter: time.Minute,
+		fetch: func(context.Context) (string, time.Time, error) {
+			fetches++
+			// expires 30s from now, inside the 1-minute jitter window, so every
+			// Get should be treated as stale and trigger a refetch.
+			return "token", time.Now().Add(30 * time.Second), nil
+		},
+	}
+
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if fetches != 2 {
+		t.Errorf("fetch called %d times, want 2 (jitter window should force a refetch each time)", fetches)
+	}
+}
+
+func TestCachedTokenGetPropagatesFetchError(t *testing.T) {
+	wantErr := errTestFetch
+	c := &cachedToken{
+		fetch: func(context.Context) (string, time.Time, error) {
+			return "", time.Time{}, wantErr
+		},
+	}
+
+	if _, err := c.Get(context.Background()); err != wantErr {
+		t.Errorf("Get() error = %v, want %v", err, wantErr)
+	}
+}
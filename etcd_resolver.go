@@ -0,0 +1,247 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"github.com/switch-bit/orlop/errors"
+	"github.com/switch-bit/orlop/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/resolver"
+)
+
+// EtcdScheme is the resolver.Builder scheme registered for etcd-backed service discovery,
+// e.g. a client URL of "etcd:///my-service".
+const EtcdScheme = "etcd"
+
+// EtcdDiscoveryConfig configures resolution of service names through etcd v3, and is
+// consulted by ConnectContext whenever cfg.GetURL() uses the "etcd" scheme.
+type EtcdDiscoveryConfig struct {
+	// Endpoints are the etcd cluster member addresses.
+	Endpoints []string
+
+	// TLS secures the connection to the etcd cluster itself, built the same way as
+	// any other client TLS config via NewClientTLSConfig.
+	TLS HasTLSConfig
+
+	// Prefix namespaces the keys watched for a service, defaulting to "/orlop/services"
+	// when empty. Instances are registered under <prefix>/<service>/<endpoint>.
+	Prefix string
+
+	// DialTimeout bounds the initial connection to the etcd cluster.
+	DialTimeout time.Duration
+}
+
+func (c EtcdDiscoveryConfig) prefix() string {
+	if len(c.Prefix) > 0 {
+		return c.Prefix
+	}
+
+	return "/orlop/services"
+}
+
+// clientConfig builds the clientv3.Config to dial the etcd cluster described by cfg,
+// securing it with TLS via NewClientTLSConfig when cfg.TLS is enabled.
+func (c EtcdDiscoveryConfig) clientConfig(ctx context.Context, vault HasVaultConfig) (clientv3.Config, error) {
+	cfg := clientv3.Config{
+		Endpoints:   c.Endpoints,
+		DialTimeout: c.DialTimeout,
+	}
+
+	if c.TLS != nil && c.TLS.GetEnabled() {
+		t, err := NewClientTLSConfig(ctx, c.TLS, vault)
+		if err != nil {
+			return clientv3.Config{}, errors.Wrap(err, "etcd: failed to get client TLS config")
+		}
+
+		cfg.TLS = t
+	}
+
+	return cfg, nil
+}
+
+// etcdResolverBuilder implements resolver.Builder for the "etcd" scheme, resolving
+// target.Endpoint to the set of addresses registered under <prefix>/<service>/.
+type etcdResolverBuilder struct {
+	cfg   EtcdDiscoveryConfig
+	vault HasVaultConfig
+}
+
+// RegisterEtcdResolver registers a resolver.Builder for the "etcd" scheme so that
+// ConnectContext (and grpc.Dial generally) can resolve "etcd:///<service>" targets
+// against the given etcd cluster.
+func RegisterEtcdResolver(cfg EtcdDiscoveryConfig, vault HasVaultConfig) {
+	resolver.Register(&etcdResolverBuilder{cfg: cfg, vault: vault})
+}
+
+func (b *etcdResolverBuilder) Scheme() string {
+	return EtcdScheme
+}
+
+func (b *etcdResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	clientCfg, err := b.cfg.clientConfig(context.Background(), b.vault)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := clientv3.New(clientCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "etcd: failed to connect")
+	}
+
+	service := strings.TrimPrefix(target.URL.Path, "/")
+	if len(service) == 0 {
+		service = target.Endpoint()
+	}
+
+	key := fmt.Sprintf("%s/%s/", b.cfg.prefix(), service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &etcdResolver{
+		client:  client,
+		cc:      cc,
+		key:     key,
+		ctx:     ctx,
+		cancel:  cancel,
+		backend: make(map[string]string),
+	}
+
+	if err := r.init(); err != nil {
+		cancel()
+		client.Close()
+		return nil, err
+	}
+
+	go r.watch()
+
+	return r, nil
+}
+
+// etcdResolver watches <prefix>/<service>/ in etcd and pushes the live set of
+// addresses to gRPC whenever an instance is registered or its lease expires.
+type etcdResolver struct {
+	client  *clientv3.Client
+	cc      resolver.ClientConn
+	key     string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	backend map[string]string
+}
+
+func (r *etcdResolver) init() error {
+	resp, err := r.client.Get(r.ctx, r.key, clientv3.WithPrefix())
+	if err != nil {
+		return errors.Wrap(err, "etcd: failed to list service instances")
+	}
+
+	for _, kv := range resp.Kvs {
+		r.backend[string(kv.Key)] = string(kv.Value)
+	}
+
+	return r.push()
+}
+
+func (r *etcdResolver) watch() {
+	for watchResp := range r.client.Watch(r.ctx, r.key, clientv3.WithPrefix()) {
+		if err := watchResp.Err(); err != nil {
+			log.WithError(err).Error("etcd: watch failed")
+			continue
+		}
+
+		for _, ev := range watchResp.Events {
+			switch ev.Type {
+			case clientv3.EventTypePut:
+				r.backend[string(ev.Kv.Key)] = string(ev.Kv.Value)
+			case clientv3.EventTypeDelete:
+				delete(r.backend, string(ev.Kv.Key))
+			}
+		}
+
+		if err := r.push(); err != nil {
+			log.WithError(err).Error("etcd: failed to push resolver state")
+		}
+	}
+}
+
+func (r *etcdResolver) push() error {
+	addrs := make([]resolver.Address, 0, len(r.backend))
+	for _, endpoint := range r.backend {
+		addrs = append(addrs, resolver.Address{Addr: endpoint})
+	}
+
+	return r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+func (r *etcdResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *etcdResolver) Close() {
+	r.cancel()
+	r.client.Close()
+}
+
+// Register announces endpoint as a live instance of service in etcd, keeping it alive
+// with a lease for as long as ctx remains open. Instances disappear automatically
+// (and etcdResolver stops routing to them) once the lease expires, so callers should
+// run Register for the lifetime of the server and let ctx cancellation clean it up.
+func Register(ctx context.Context, cfg EtcdDiscoveryConfig, vault HasVaultConfig, service, endpoint string) error {
+	clientCfg, err := cfg.clientConfig(ctx, vault)
+	if err != nil {
+		return err
+	}
+
+	client, err := clientv3.New(clientCfg)
+	if err != nil {
+		return errors.Wrap(err, "etcd: failed to connect")
+	}
+
+	lease, err := client.Grant(ctx, 30)
+	if err != nil {
+		client.Close()
+		return errors.Wrap(err, "etcd: failed to grant lease")
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", cfg.prefix(), service, endpoint)
+
+	if _, err := client.Put(ctx, key, endpoint, clientv3.WithLease(lease.ID)); err != nil {
+		client.Close()
+		return errors.Wrap(err, "etcd: failed to register instance")
+	}
+
+	keepAlive, err := client.KeepAlive(ctx, lease.ID)
+	if err != nil {
+		client.Close()
+		return errors.Wrap(err, "etcd: failed to keep lease alive")
+	}
+
+	go func() {
+		defer client.Close()
+
+		for range keepAlive {
+			// drain keep-alive responses until ctx is cancelled or the lease is lost
+		}
+	}()
+
+	return nil
+}
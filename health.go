@@ -0,0 +1,118 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"strings"
+	"time"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+// healthServer is the process-wide health.Server backing SetServingStatus. It is
+// registered against every server created through this package.
+var healthServer = health.NewServer()
+
+// RegisterHealthServer registers the standard grpc_health_v1 health service on s,
+// so that clients using HealthCheckConfig or ConnectContext's GetHealthCheck can
+// observe per-service readiness.
+func RegisterHealthServer(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, healthServer)
+}
+
+// SetServingStatus reports the current health of service (use "" for the overall
+// server status) so that health-aware clients and load balancers can react to it.
+func SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	healthServer.SetServingStatus(service, status)
+}
+
+// HealthCheckConfig controls whether ConnectContext waits for the target to report
+// healthy before returning, and is consulted together with GetBlock.
+type HealthCheckConfig struct {
+	// Enabled turns on the health-aware dial. Has no effect unless GetBlock is also set.
+	Enabled bool
+
+	// Service is the service name reported to healthpb.HealthCheckRequest, empty
+	// meaning the server's overall status.
+	Service string
+
+	// Timeout bounds how long to wait for a SERVING status before giving up.
+	Timeout time.Duration
+}
+
+// waitForServing blocks on conn until the health service reports SERVING for
+// cfg.Service, or returns an error once cfg.Timeout elapses or the RPC itself fails.
+func waitForServing(ctx context.Context, conn *grpc.ClientConn, cfg HealthCheckConfig) error {
+	ctx, span := tracer.Start(ctx, "HealthCheck")
+	defer span.End()
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	client := healthpb.NewHealthClient(conn)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: cfg.Service})
+	if err != nil {
+		span.RecordError(ctx, err)
+		return errors.Wrap(err, "client: failed to watch health")
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			span.RecordError(ctx, err)
+			return errors.Wrap(err, "client: health watch failed")
+		}
+
+		if resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+			return nil
+		}
+	}
+}
+
+// clientServiceConfig builds the gRPC service config JSON passed to
+// grpc.WithDefaultServiceConfig, combining the etcd round_robin policy and the
+// built-in health-checking load-balancer behavior when either is enabled for cfg.
+// It returns an empty string when neither applies, leaving gRPC's defaults in place.
+func clientServiceConfig(cfg HasClientConfig) string {
+	var parts []string
+
+	if strings.HasPrefix(cfg.GetURL(), EtcdScheme+":") {
+		parts = append(parts, `"loadBalancingPolicy":"round_robin"`)
+	}
+
+	if health := cfg.GetHealthCheck(); health.Enabled {
+		parts = append(parts, `"healthCheckConfig":{"serviceName":"`+health.Service+`"}`)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"testing"
+)
+
+// fakeClientConfig implements HasClientConfig with just the accessors
+// clientServiceConfig reads; every other method embeds a nil HasClientConfig so
+// this satisfies the interface without restating its full accessor set here.
+type fakeClientConfig struct {
+	HasClientConfig
+	url    string
+	health HealthCheckConfig
+}
+
+func (f fakeClientConfig) GetURL() string                   { return f.url }
+func (f fakeClientConfig) GetHealthCheck() HealthCheckConfig { return f.health }
+
+func TestClientServiceConfig(t *testing.T) {
+	if got := clientServiceConfig(fakeClientConfig{}); got != "" {
+		t.Errorf("clientServiceConfig(empty) = %q, want empty string", got)
+	}
+
+	got := clientServiceConfig(fakeClientConfig{url: "etcd:///my-service"})
+	want := `{"loadBalancingPolicy":"round_robin"}`
+	if got != want {
+		t.Errorf("clientServiceConfig(etcd) = %q, want %q", got, want)
+	}
+
+	got = clientServiceConfig(fakeClientConfig{health: HealthCheckConfig{Enabled: true, Service: "my-service"}})
+	want = `{"healthCheckConfig":{"serviceName":"my-service"}}`
+	if got != want {
+		t.Errorf("clientServiceConfig(health) = %q, want %q", got, want)
+	}
+
+	got = clientServiceConfig(fakeClientConfig{url: "etcd:///my-service", health: HealthCheckConfig{Enabled: true, Service: "my-service"}})
+	want = `{"loadBalancingPolicy":"round_robin","healthCheckConfig":{"serviceName":"my-service"}}`
+	if got != want {
+		t.Errorf("clientServiceConfig(etcd+health) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"fmt"
+	"github.com/switch-bit/orlop/errors"
+)
+
+// frame is the message type the proxy's codec passes through both legs: the raw wire
+// bytes of whatever protobuf the client sent, left unmarshalled.
+type frame struct {
+	payload []byte
+}
+
+// rawCodec is a grpc.Codec that passes message bytes through unchanged, so the proxy
+// never has to know the schema of what it's forwarding.
+type rawCodec struct{}
+
+func (rawCodec) String() string {
+	return "proxy"
+}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	f, ok := v.(*frame)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("proxy: codec cannot marshal %T", v))
+	}
+
+	return f.payload, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	f, ok := v.(*frame)
+	if !ok {
+		return errors.New(fmt.Sprintf("proxy: codec cannot unmarshal into %T", v))
+	}
+
+	f.payload = data
+
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRawCodecRoundTrip(t *testing.T) {
+	want := []byte{0x0a, 0x03, 'f', 'o', 'o'}
+
+	f := &frame{}
+	if err := (rawCodec{}).Unmarshal(want, f); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	got, err := (rawCodec{}).Marshal(f)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Marshal/Unmarshal round trip mutated payload: got %v, want %v", got, want)
+	}
+}
+
+func TestRawCodecRejectsWrongType(t *testing.T) {
+	if _, err := (rawCodec{}).Marshal("not a frame"); err == nil {
+		t.Fatal("expected Marshal to reject a non-*frame value")
+	}
+
+	if err := (rawCodec{}).Unmarshal(nil, "not a frame"); err == nil {
+		t.Fatal("expected Unmarshal to reject a non-*frame value")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package proxy turns an orlop server into a transparent gRPC reverse proxy,
+// forwarding any method it doesn't itself implement to an upstream dialled with
+// orlop.ConnectContext, so TLS, tokens, retries and tracing all carry over unchanged.
+package proxy
+
+import (
+	"context"
+	"io"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/switch-bit/orlop/errors"
+)
+
+// hopByHopHeaders are stripped from incoming metadata before it is forwarded
+// upstream, since they describe the incoming transport rather than the call.
+var hopByHopHeaders = map[string]bool{
+	":authority":   true,
+	"content-type": true,
+	"user-agent":   true,
+}
+
+// Director routes an incoming call by its fullMethodName to an upstream connection,
+// returning the context to use for the upstream call (e.g. with metadata attached or
+// stripped) alongside the *grpc.ClientConn to forward it to.
+type Director func(ctx context.Context, fullMethodName string) (context.Context, *grpc.ClientConn, error)
+
+// ServerOptions returns the grpc.ServerOptions that turn a grpc.Server into a
+// transparent proxy fronting director's upstreams. They must be passed to
+// grpc.NewServer alongside orlop's own server options, since the raw codec and the
+// unknown-service handler can only be set at server construction:
+//
+//	srv := grpc.NewServer(append(orlop.ServerOptions(cfg), proxy.ServerOptions(director)...)...)
+//
+// Any method not otherwise registered on the resulting server is transparently
+// proxied to director's chosen upstream.
+func ServerOptions(director Director) []grpc.ServerOption {
+	h := &handler{director: director}
+
+	return []grpc.ServerOption{
+		grpc.CustomCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(h.handle),
+	}
+}
+
+// handler implements the bidirectional-streaming RPC used to proxy every method.
+type handler struct {
+	director Director
+}
+
+func (h *handler) handle(_ interface{}, serverStream grpc.ServerStream) error {
+	ctx := serverStream.Context()
+
+	fullMethodName, ok := grpc.MethodFromServerStream(serverStream)
+	if !ok {
+		return errors.New("proxy: could not determine method from server stream")
+	}
+
+	outgoingCtx, conn, err := h.director(ctx, fullMethodName)
+	if err != nil {
+		return errors.Wrap(err, "proxy: director failed to route "+fullMethodName)
+	}
+
+	outgoingCtx = forwardMetadata(ctx, outgoingCtx)
+
+	clientCtx, clientCancel := context.WithCancel(outgoingCtx)
+	defer clientCancel()
+
+	// grpc.CallCustomCodec is the client-side counterpart to the grpc.CustomCodec
+	// server option installed by ServerOptions: without it NewClientStream falls
+	// back to the default proto codec and SendMsg/RecvMsg reject *frame outright.
+	clientStream, err := grpc.NewClientStream(clientCtx, &grpc.StreamDesc{
+		StreamName:    fullMethodName,
+		ServerStreams: true,
+		ClientStreams: true,
+	}, conn, fullMethodName, grpc.CallCustomCodec(rawCodec{}))
+	if err != nil {
+		return errors.Wrap(err, "proxy: failed to open upstream stream for "+fullMethodName)
+	}
+
+	// Splice the two streams: one goroutine pumps client->upstream, and a second,
+	// started by forwardUpstream, pumps upstream->client, so headers/trailers/status
+	// can be forwarded faithfully as soon as the upstream side resolves them.
+	clientDone := make(chan error, 1)
+	go func() {
+		err := pump(func() (*frame, error) {
+			f := new(frame)
+			err := serverStream.RecvMsg(f)
+			return f, err
+		}, clientStream.SendMsg)
+
+		if err == nil {
+			// Half-close the upstream leg so client/bidi-streaming handlers that loop
+			// on Recv until io.EOF see the client is done sending, instead of hanging.
+			err = clientStream.CloseSend()
+		}
+
+		clientDone <- err
+	}()
+
+	serverDone := forwardUpstream(serverStream, clientStream)
+
+	// A clean half-close from the client leaves the upstream call running so its
+	// response, trailers and status still reach the client; a failed client leg, or
+	// the upstream leg finishing, cancels clientCtx so the other one is no longer
+	// blocked on the upstream stream. Both legs are then waited for: returning from
+	// handle ends the RPC, so nothing may still be touching serverStream/clientStream
+	// from a goroutine we haven't confirmed has exited.
+	var clientErr, serverErr error
+	select {
+	case clientErr = <-clientDone:
+		if clientErr != nil {
+			clientCancel()
+		}
+		serverErr = <-serverDone
+	case serverErr = <-serverDone:
+		clientCancel()
+		clientErr = <-clientDone
+	}
+
+	if clientErr != nil && clientErr != io.EOF {
+		return clientErr
+	}
+
+	return serverErr
+}
+
+// forwardUpstream pumps messages from the upstream clientStream to the downstream
+// serverStream, forwarding headers as they arrive and propagating the final trailers
+// and status once the upstream stream ends.
+func forwardUpstream(serverStream grpc.ServerStream, clientStream grpc.ClientStream) <-chan error {
+	done := make(chan error, 1)
+
+	go func() {
+		md, err := clientStream.Header()
+		if err != nil {
+			done <- errors.Wrap(err, "proxy: failed to read upstream headers")
+			return
+		}
+
+		if err := serverStream.SendHeader(md); err != nil {
+			done <- errors.Wrap(err, "proxy: failed to send downstream headers")
+			return
+		}
+
+		err = pump(func() (*frame, error) {
+			f := new(frame)
+			err := clientStream.RecvMsg(f)
+			return f, err
+		}, serverStream.SendMsg)
+
+		serverStream.SetTrailer(clientStream.Trailer())
+
+		if err != nil && err != io.EOF {
+			done <- err
+			return
+		}
+
+		done <- nil
+	}()
+
+	return done
+}
+
+// pump relays frames from recv to send until recv returns io.EOF or another error.
+func pump(recv func() (*frame, error), send func(interface{}) error) error {
+	for {
+		f, err := recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := send(f); err != nil {
+			return err
+		}
+	}
+}
+
+// forwardMetadata merges incoming's metadata (minus hop-by-hop headers) into whatever
+// outgoing metadata the Director already attached to outgoing, rather than replacing
+// it, so a Director that adds e.g. an auth header isn't silently overridden.
+func forwardMetadata(incoming, outgoing context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(incoming)
+	if !ok {
+		return outgoing
+	}
+
+	merged, _ := metadata.FromOutgoingContext(outgoing)
+	merged = merged.Copy()
+
+	for k, v := range md {
+		if hopByHopHeaders[k] {
+			continue
+		}
+
+		merged[k] = v
+	}
+
+	return metadata.NewOutgoingContext(outgoing, merged)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package proxy
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPumpForwardsUntilEOF(t *testing.T) {
+	in := []*frame{{payload: []byte("a")}, {payload: []byte("b")}}
+	i := 0
+
+	recv := func() (*frame, error) {
+		if i >= len(in) {
+			return nil, io.EOF
+		}
+
+		f := in[i]
+		i++
+		return f, nil
+	}
+
+	var got []*frame
+	send := func(v interface{}) error {
+		got = append(got, v.(*frame))
+		return nil
+	}
+
+	if err := pump(recv, send); err != nil {
+		t.Fatalf("pump returned error: %v", err)
+	}
+
+	if len(got) != len(in) {
+		t.Fatalf("expected %d forwarded frames, got %d", len(in), len(got))
+	}
+}
+
+func TestPumpStopsOnSendError(t *testing.T) {
+	recv := func() (*frame, error) {
+		return &frame{payload: []byte("a")}, nil
+	}
+
+	wantErr := errors.New("boom")
+	send := func(interface{}) error {
+		return wantErr
+	}
+
+	if err := pump(recv, send); err != wantErr {
+		t.Fatalf("expected pump to propagate send error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+	"github.com/sirupsen/logrus"
+	"github.com/switch-bit/orlop/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// retrySpan is the subset of the tracing span used by the retry interceptors, kept
+// narrow so it's satisfied by whatever tracer.Start returns. Only RecordError is used,
+// since it's the only span method already proven out elsewhere in this package.
+type retrySpan interface {
+	RecordError(ctx context.Context, err error)
+}
+
+// logRetry records a single retry attempt at Trace level, mirroring the
+// log.WithContext(ctx).WithFields(...).Trace(...) pattern already used for dialling.
+func logRetry(ctx context.Context, method string, attempt int, code, wait string) {
+	log.WithContext(ctx).WithFields(logrus.Fields{
+		"method":  method,
+		"attempt": attempt,
+		"code":    code,
+		"wait":    wait,
+	}).Trace("retrying rpc")
+}
+
+// defaultRandomizationFactor is used when RetryConfig.RandomizationFactor is unset.
+const defaultRandomizationFactor = 0.5
+
+// retryPushbackTrailer is the trailer servers use to explicitly control client backoff,
+// per the gRPC retry design.
+const retryPushbackTrailer = "grpc-retry-pushback-ms"
+
+// RetryConfig controls the automatic client-side retry of idempotent RPCs performed by
+// ConnectContext. It is tuned per-service alongside TLS and token configuration on
+// HasClientConfig.
+type RetryConfig struct {
+	// MaxAttempts is the total number of times an RPC will be attempted, including the
+	// initial try. A value of 0 or 1 disables retries.
+	MaxAttempts int
+
+	// InitialInterval is the backoff used ahead of the first retry.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the computed backoff, regardless of attempt count.
+	MaxInterval time.Duration
+
+	// Multiplier grows the backoff on each subsequent attempt.
+	Multiplier float64
+
+	// RandomizationFactor jitters the computed backoff into the range
+	// [interval*(1-r), interval*(1+r)]. Defaults to 0.5 when unset.
+	RandomizationFactor float64
+
+	// RetryableCodes lists the gRPC status codes that are safe to retry.
+	RetryableCodes []codes.Code
+
+	// PerAttemptTimeout, when set, bounds an individual attempt rather than the call
+	// as a whole.
+	PerAttemptTimeout time.Duration
+}
+
+// enabled reports whether the config describes any retry behavior at all.
+func (c RetryConfig) enabled() bool {
+	return c.MaxAttempts > 1
+}
+
+// retryable reports whether code is safe to retry under this config.
+func (c RetryConfig) retryable(code codes.Code) bool {
+	for _, rc := range c.RetryableCodes {
+		if rc == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff computes the jittered interval to wait before the given zero-based retry attempt.
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	interval := float64(c.InitialInterval) * math.Pow(c.Multiplier, float64(attempt))
+	if max := float64(c.MaxInterval); max > 0 && interval > max {
+		interval = max
+	}
+
+	r := c.RandomizationFactor
+	if r == 0 {
+		r = defaultRandomizationFactor
+	}
+
+	delta := interval * r
+	lo := interval - delta
+	hi := interval + delta
+
+	return time.Duration(lo + rand.Float64()*(hi-lo))
+}
+
+// pushback parses the grpc-retry-pushback-ms trailer, if present. ok is false when the
+// server sent no guidance. abort is true when the server asked us to stop retrying
+// entirely (a negative value).
+func pushback(md metadata.MD) (d time.Duration, ok bool, abort bool) {
+	vals := md.Get(retryPushbackTrailer)
+	if len(vals) == 0 {
+		return 0, false, false
+	}
+
+	ms, err := strconv.ParseInt(vals[0], 10, 64)
+	if err != nil {
+		return 0, false, false
+	}
+
+	if ms < 0 {
+		return 0, true, true
+	}
+
+	return time.Duration(ms) * time.Millisecond, true, false
+}
+
+// sleep waits for d, or returns ctx.Err() if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// unaryRetryInterceptor returns a grpc.UnaryClientInterceptor that retries idempotent
+// RPCs per cfg, backing off between attempts and honoring server pushback.
+func unaryRetryInterceptor(cfg RetryConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracer.Start(ctx, "Retry")
+		defer span.End()
+
+		var trailer metadata.MD
+		callOpts := append(append([]grpc.CallOption{}, opts...), grpc.Trailer(&trailer))
+
+		var lastErr error
+		for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
+			attemptCtx := ctx
+			cancel := func() {}
+			if cfg.PerAttemptTimeout > 0 {
+				attemptCtx, cancel = context.WithTimeout(ctx, cfg.PerAttemptTimeout)
+			}
+
+			trailer = metadata.MD{}
+			lastErr = invoker(attemptCtx, method, req, reply, cc, callOpts...)
+			cancel()
+			if lastErr == nil {
+				return nil
+			}
+
+			if attempt == cfg.MaxAttempts-1 || !cfg.retryable(status.Code(lastErr)) {
+				span.RecordError(ctx, lastErr)
+				return lastErr
+			}
+
+			wait := cfg.backoff(attempt)
+			if d, ok, abort := pushback(trailer); ok {
+				if abort {
+					span.RecordError(ctx, lastErr)
+					return lastErr
+				}
+				wait = d
+			}
+
+			logRetry(ctx, method, attempt+1, status.Code(lastErr).String(), wait.String())
+
+			if err := sleep(ctx, wait); err != nil {
+				return lastErr
+			}
+		}
+
+		return lastErr
+	}
+}
+
+// streamRetryInterceptor returns a grpc.StreamClientInterceptor that retries a stream
+// from the beginning per cfg, as long as no message has yet been received on it.
+func streamRetryInterceptor(cfg RetryConfig) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := tracer.Start(ctx, "Retry")
+		defer span.End()
+
+		var attempt int
+
+		open := func() (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		stream, err := open()
+		if err != nil {
+			span.RecordError(ctx, err)
+			return nil, err
+		}
+
+		return &retryClientStream{
+			ClientStream: stream,
+			ctx:          ctx,
+			span:         span,
+			cfg:          cfg,
+			method:       method,
+			attempt:      &attempt,
+			reopen:       open,
+		}, nil
+	}
+}
+
+// retryClientStream wraps a grpc.ClientStream, transparently re-establishing and
+// retrying it as long as no message has been received yet.
+type retryClientStream struct {
+	grpc.ClientStream
+	ctx      context.Context
+	span     retrySpan
+	cfg      RetryConfig
+	method   string
+	attempt  *int
+	reopen   func() (grpc.ClientStream, error)
+	received bool
+}
+
+func (s *retryClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.received = true
+		return nil
+	}
+
+	if s.received || *s.attempt >= s.cfg.MaxAttempts-1 || !s.cfg.retryable(status.Code(err)) {
+		s.span.RecordError(s.ctx, err)
+		return err
+	}
+
+	wait := s.cfg.backoff(*s.attempt)
+	logRetry(s.ctx, s.method, *s.attempt+1, status.Code(err).String(), wait.String())
+
+	if sleepErr := sleep(s.ctx, wait); sleepErr != nil {
+		return err
+	}
+
+	*s.attempt++
+
+	newStream, reopenErr := s.reopen()
+	if reopenErr != nil {
+		return reopenErr
+	}
+
+	s.ClientStream = newStream
+
+	return s.RecvMsg(m)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 SwitchBit, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package orlop
+
+import (
+	"testing"
+	"time"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRetryConfigBackoff(t *testing.T) {
+	cfg := RetryConfig{
+		InitialInterval:     100 * time.Millisecond,
+		MaxInterval:         time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 0.5,
+	}
+
+	for attempt, want := range map[int]time.Duration{
+		0: 100 * time.Millisecond,
+		1: 200 * time.Millisecond,
+		2: 400 * time.Millisecond,
+		5: time.Second, // capped by MaxInterval
+	} {
+		lo := time.Duration(float64(want) * 0.5)
+		hi := time.Duration(float64(want) * 1.5)
+
+		got := cfg.backoff(attempt)
+		if got < lo || got > hi {
+			t.Errorf("backoff(%d) = %v, want in [%v, %v]", attempt, got, lo, hi)
+		}
+	}
+}
+
+func TestRetryConfigRetryable(t *testing.T) {
+	cfg := RetryConfig{RetryableCodes: []codes.Code{codes.Unavailable, codes.Aborted}}
+
+	if !cfg.retryable(codes.Unavailable) {
+		t.Error("expected UNAVAILABLE to be retryable")
+	}
+
+	if cfg.retryable(codes.InvalidArgument) {
+		t.Error("expected INVALID_ARGUMENT not to be retryable")
+	}
+}
+
+func TestPushback(t *testing.T) {
+	if _, ok, _ := pushback(metadata.MD{}); ok {
+		t.Error("expected no pushback when trailer is absent")
+	}
+
+	d, ok, abort := pushback(metadata.Pairs(retryPushbackTrailer, "250"))
+	if !ok || abort || d != 250*time.Millisecond {
+		t.Errorf("pushback(250) = (%v, %v, %v), want (250ms, true, false)", d, ok, abort)
+	}
+
+	_, ok, abort = pushback(metadata.Pairs(retryPushbackTrailer, "-1"))
+	if !ok || !abort {
+		t.Errorf("pushback(-1) should report abort, got ok=%v abort=%v", ok, abort)
+	}
+
+	if _, ok, _ := pushback(metadata.Pairs(retryPushbackTrailer, "not-a-number")); ok {
+		t.Error("expected a malformed trailer to be ignored")
+	}
+}